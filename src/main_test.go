@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWriteReadClusterBlockRoundTrip(t *testing.T) {
+	block := make([]byte, 64)
+	writeClusterBlock(block, 7, 12345)
+
+	id, seq, crcOK := readClusterBlock(block)
+	if !crcOK {
+		t.Fatalf("crcOK = false, want true")
+	}
+	if id != 7 {
+		t.Errorf("id = %d, want 7", id)
+	}
+	if seq != 12345 {
+		t.Errorf("seq = %d, want 12345", seq)
+	}
+}
+
+func TestReadClusterBlockDetectsTornWrite(t *testing.T) {
+	a := make([]byte, 64)
+	writeClusterBlock(a, 1, 1)
+	b := make([]byte, 64)
+	writeClusterBlock(b, 2, 2)
+
+	// Simulate a torn write: the first half landed from one write, the
+	// second half from another.
+	torn := append(append([]byte{}, a[:32]...), b[32:]...)
+
+	if _, _, crcOK := readClusterBlock(torn); crcOK {
+		t.Errorf("crcOK = true for a torn block, want false")
+	}
+}
+
+func TestLatencyHistogramPercentile(t *testing.T) {
+	h := &latencyHistogram{}
+	for i := 1; i <= 100; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+
+	if got := h.percentile(50); got != 50*time.Millisecond {
+		t.Errorf("p50 = %v, want 50ms", got)
+	}
+	if got := h.percentile(99); got != 99*time.Millisecond {
+		t.Errorf("p99 = %v, want 99ms", got)
+	}
+	if got := h.percentile(100); got != 100*time.Millisecond {
+		t.Errorf("p100 = %v, want 100ms", got)
+	}
+}
+
+func TestLatencyHistogramPercentileEmpty(t *testing.T) {
+	h := &latencyHistogram{}
+	if got := h.percentile(50); got != 0 {
+		t.Errorf("percentile on an empty histogram = %v, want 0", got)
+	}
+}